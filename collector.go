@@ -0,0 +1,51 @@
+package glow
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	sentDesc = prometheus.NewDesc(
+		"glow_channel_sent_total", "Total values sent on a glow connection.",
+		[]string{"channel"}, nil)
+	recvDesc = prometheus.NewDesc(
+		"glow_channel_recv_total", "Total values received on a glow connection.",
+		[]string{"channel"}, nil)
+	occupancyDesc = prometheus.NewDesc(
+		"glow_channel_occupancy", "Current number of buffered values on a glow connection.",
+		[]string{"channel"}, nil)
+	fullnessDesc = prometheus.NewDesc(
+		"glow_channel_fullness_integral_seconds", "Time-integrated occupancy/capacity of a glow connection.",
+		[]string{"channel"}, nil)
+)
+
+// graphCollector adapts a Graph's Stats to the prometheus.Collector
+// interface so it can be registered with a promhttp handler.
+type graphCollector struct {
+	graph *Graph
+}
+
+// Collector: Return a prometheus.Collector exposing this graph's channel
+// statistics, suitable for prometheus.Register and promhttp.Handler.
+func (g *Graph) Collector() prometheus.Collector {
+	return &graphCollector{graph: g}
+}
+
+// Describe implements prometheus.Collector. The set of "channel" label
+// values isn't known until the graph is connected, so each metric is
+// described once, with "channel" as a variable label.
+func (c *graphCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sentDesc
+	ch <- recvDesc
+	ch <- occupancyDesc
+	ch <- fullnessDesc
+}
+
+func (c *graphCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, st := range c.graph.Stats() {
+		ch <- prometheus.MustNewConstMetric(sentDesc, prometheus.CounterValue, float64(st.Sent), name)
+		ch <- prometheus.MustNewConstMetric(recvDesc, prometheus.CounterValue, float64(st.Recv), name)
+		ch <- prometheus.MustNewConstMetric(occupancyDesc, prometheus.GaugeValue, float64(st.Occupancy), name)
+		ch <- prometheus.MustNewConstMetric(fullnessDesc, prometheus.CounterValue, st.FullnessIntegral, name)
+	}
+}
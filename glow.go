@@ -14,11 +14,12 @@ type Argument struct {
 
 // ----------------------------------------------------------------------------
 type Node struct {
-	name string        // The node's name.
-	fi   interface{}   // The node's run function.
-	ft   reflect.Type  // The function's type.
-	fv   reflect.Value // The function's value.
-	args []Argument
+	name  string        // The node's name.
+	fi    interface{}   // The node's run function.
+	ft    reflect.Type  // The function's type.
+	fv    reflect.Value // The function's value.
+	args  []Argument
+	attrs map[string]string // Graphviz attributes set via SetNodeAttr.
 }
 
 func NewNode(fn interface{}, name string, argNames ...string) *Node {
@@ -74,24 +75,50 @@ func (node *Node) SetArg(name string, val reflect.Value) {
 	panic("Argument not found.")
 }
 
-func (node *Node) DotString() string {
-	s := node.name + " [\n"
-	s += "label = \"" + node.name
+// UnsetArgs: Return the names of arguments that have not yet been given a
+// value via SetArg.
+func (node *Node) UnsetArgs() []string {
+	var names []string
+	for _, arg := range node.args {
+		if !arg.val.IsValid() {
+			names = append(names, arg.name)
+		}
+	}
+	return names
+}
+
+// ArgType: Return the reflect.Type expected for the named argument (this is
+// the full channel type, e.g. "chan int", not its element type), and
+// whether an argument by that name exists.
+func (node *Node) ArgType(name string) (reflect.Type, bool) {
+	for _, arg := range node.args {
+		if arg.name == name {
+			return arg.Type, true
+		}
+	}
+	return nil, false
+}
+
+// recordLabel builds the graphviz record label used to render this node,
+// with one field per non-globals argument.
+func (node *Node) recordLabel() string {
+	s := node.name
 	for _, arg := range node.args[1:] {
 		s += "|<" + arg.name + ">" + arg.name
 	}
-	s += "\"\n"
-	s += "shape = record\n]"
 	return s
 }
 
 // ----------------------------------------------------------------------------
 type Graph struct {
-	connStr  []string         // List of connections for dot file output.
-	lastChan int              // Last channel number for dot file output.
-	nodes    map[string]*Node // Map from node name to node.
-	globals  reflect.Value    // Globals passed to each node.
-	fgName   string           // Name of node to run in the foreground.
+	lastChan int                        // Last channel number, used to name channels for rendering.
+	nodes    map[string]*Node           // Map from node name to node.
+	conns    []connection               // Connections, in the order Connect was called.
+	clusters map[string][]string        // Map from cluster name to the node names it contains.
+	runtimes map[string]*channelRuntime // Map from connection name to its instrumentation, if any.
+	remotes  map[string]string          // Map from node name to transport address, for nodes added via glow/remote.
+	globals  reflect.Value              // Globals passed to each node.
+	fgName   string                     // Name of node to run in the foreground.
 }
 
 // NewGraph: Construct a new empty graph object. The value of globals
@@ -99,6 +126,13 @@ type Graph struct {
 func NewGraph(globals interface{}) *Graph {
 	graph := new(Graph)
 	graph.globals = reflect.ValueOf(globals)
+	if !graph.globals.IsValid() {
+		// globals was a literal nil: reflect.ValueOf(nil) has no type to
+		// unwrap, so it comes back invalid. That's a legitimate "no shared
+		// state" graph, not an unset argument, so fall back to a valid
+		// Value of type interface{} holding nil.
+		graph.globals = reflect.ValueOf(&globals).Elem()
+	}
 	graph.nodes = make(map[string]*Node)
 	return graph
 }
@@ -123,25 +157,56 @@ func (g *Graph) AddNode(fn interface{}, name string, argNames ...string) {
 // the channel buffer is the first argument. Additional arguments list the
 // nodes that will be using the channel. The format for these arguments is
 // "NodeName:ChannelName".
-// Returns the new channel as a reflect.Value.
+//
+// If nodeChans includes both a sending endpoint (a port name ending in
+// "Out") and a receiving endpoint, the connection is instrumented: traffic
+// on it shows up in Graph.Stats and Graph.LiveDotString. Returns the
+// channel passed to the sending endpoint(s) as a reflect.Value.
 func (g *Graph) Connect(size int, nodeChans ...string) reflect.Value {
 	name, port := splitNamePort(nodeChans[0])
 	ch := g.nodes[name].MakeChan(port, size)
 
 	chName := fmt.Sprintf("chan_%v", g.lastChan)
 	g.lastChan += 1
-	g.connStr = append(g.connStr,
-		fmt.Sprintf("%v [\nlabel=\"%v\"\n]", chName, size))
+	g.conns = append(g.conns, connection{
+		chanName:  chName,
+		size:      size,
+		elemType:  ch.Type().Elem(),
+		endpoints: append([]string(nil), nodeChans...),
+	})
 
+	var hasOut, hasIn bool
 	for _, s := range nodeChans {
-		name, port = splitNamePort(s)
-		g.nodes[name].SetArg(port, ch)
-
-		if strings.HasSuffix(port, "Out") {
-			g.connStr = append(g.connStr, name+":"+port+"->"+chName)
+		_, port = splitNamePort(s)
+		if isOutPort(port) {
+			hasOut = true
 		} else {
-			g.connStr = append(g.connStr, chName+"->"+name+":"+port)
+			hasIn = true
+		}
+	}
+
+	if hasOut && hasIn {
+		rt := newChannelRuntime(chName, size, ch.Type())
+		if g.runtimes == nil {
+			g.runtimes = make(map[string]*channelRuntime)
 		}
+		g.runtimes[chName] = rt
+		go rt.relay()
+
+		for _, s := range nodeChans {
+			name, port = splitNamePort(s)
+			if isOutPort(port) {
+				g.nodes[name].SetArg(port, rt.producer)
+			} else {
+				g.nodes[name].SetArg(port, rt.consumer)
+			}
+		}
+		return rt.producer
+	}
+
+	for _, s := range nodeChans {
+		name, port = splitNamePort(s)
+		g.nodes[name].SetArg(port, ch)
 	}
 	return ch
 }
@@ -152,18 +217,47 @@ func (g *Graph) SetForeground(name string) {
 	g.fgName = name
 }
 
+// GlobalsType: Return the reflect.Type of the globals value this graph was
+// constructed with. Useful to packages built on top of glow, such as
+// glow/remote, that need to synthesize node functions via reflect.MakeFunc.
+// If the graph has no globals (NewGraph(nil)), returns the type of the
+// empty interface rather than panicking.
+func (g *Graph) GlobalsType() reflect.Type {
+	if !g.globals.IsValid() {
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	return g.globals.Type()
+}
+
+// Node: Look up a node by name, returning false if no such node exists.
+// This is mainly useful to packages built on top of glow, such as glow/spec,
+// that need to validate a graph before wiring it together.
+func (g *Graph) Node(name string) (*Node, bool) {
+	node, ok := g.nodes[name]
+	return node, ok
+}
+
+// MarkRemote: Record that the named node is actually implemented by a
+// separate process reachable at addr. This is used by glow/remote's
+// AddRemoteNode; Render and DotString label any edge crossing into a
+// remote node with its transport address.
+func (g *Graph) MarkRemote(name, addr string) {
+	if g.remotes == nil {
+		g.remotes = make(map[string]string)
+	}
+	g.remotes[name] = addr
+}
+
 // DotString: Return a string containing a dot file suitable for processing
-// by graphviz. On Linux, xdot can be used to view a dot file directly.
+// by graphviz. On Linux, xdot can be used to view a dot file directly. This
+// is a convenience wrapper around Render with default RenderOptions; use
+// Render directly for control over global attributes, clusters and
+// per-node styling.
 func (g *Graph) DotString() string {
-	s := "digraph {"
-	s += "\ngraph [ rankdir=\"LR\" ];"
-	for _, node := range g.nodes {
-		s += "\n" + node.DotString()
-	}
-	for _, conn := range g.connStr {
-		s += "\n" + conn
+	s, err := g.Render(RenderOptions{})
+	if err != nil {
+		return "// error rendering graph: " + err.Error()
 	}
-	s += "\n}"
 	return s
 }
 
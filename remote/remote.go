@@ -0,0 +1,30 @@
+// Package remote lets a glow node run in a separate process (or on another
+// machine), wired into the parent *glow.Graph as if it were local. Values
+// crossing the process boundary are gob-encoded over a TCP connection.
+package remote
+
+// RemoteArg describes one channel argument of a remote node: its name, as
+// used both on the local Graph (via AddRemoteNode) and on the remote
+// Serve call, and a zero value of the channel's element type. Elem is used
+// to build the local proxy channel and to register the type with
+// encoding/gob.
+type RemoteArg struct {
+	Name string
+	Elem interface{}
+}
+
+// frame is the wire format for a single value crossing the process
+// boundary. Arg identifies which channel the value belongs to, so that
+// several channels can be multiplexed over one connection.
+type frame struct {
+	Arg   string
+	Value interface{}
+	Close bool
+}
+
+// handshake is sent once, right after dialing, identifying the remote node
+// being connected to.
+type handshake struct {
+	Name string
+	Args []string
+}
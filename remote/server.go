@@ -0,0 +1,74 @@
+package remote
+
+import (
+	"encoding/gob"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/johnnylee/glow"
+)
+
+// chanBufSize is the buffer used for the local proxy channels Serve
+// creates for each argument; it only needs to smooth out scheduling
+// between the node's own goroutine and the transport goroutines, not to
+// match the buffer sizes used on the graph side of the connection.
+const chanBufSize = 16
+
+// Serve listens on addr and, for each incoming connection, runs fn as a
+// node named name with the given argument names, relaying its channel
+// arguments to and from the connection. It blocks, serving connections
+// until Accept fails (e.g. the listener is closed), at which point it
+// returns the error that caused it to stop.
+func Serve(addr string, fn interface{}, globals interface{}, name string, argNames ...string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("remote: listen on %s: %w", addr, err)
+	}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return fmt.Errorf("remote: accept: %w", err)
+		}
+		go serveConn(conn, fn, globals, name, argNames)
+	}
+}
+
+func serveConn(conn net.Conn, fn interface{}, globals interface{}, name string, argNames []string) {
+	defer conn.Close()
+
+	enc := gob.NewEncoder(conn)
+	dec := gob.NewDecoder(conn)
+
+	var hs handshake
+	if err := dec.Decode(&hs); err != nil || hs.Name != name {
+		return
+	}
+
+	node := glow.NewNode(fn, name, argNames...)
+	node.SetArg("globals", reflect.ValueOf(globals))
+
+	t := newTransport(enc, dec)
+	inChans := make(map[string]reflect.Value)
+
+	for _, argName := range argNames {
+		chanType, ok := node.ArgType(argName)
+		if !ok {
+			return
+		}
+		ch := reflect.MakeChan(chanType, chanBufSize)
+		node.SetArg(argName, ch)
+		gob.Register(reflect.New(chanType.Elem()).Elem().Interface())
+
+		if strings.HasSuffix(argName, "Out") {
+			go t.send(argName, ch)
+		} else {
+			inChans[argName] = ch
+		}
+	}
+
+	go t.demux(inChans)
+	node.Run()
+}
@@ -0,0 +1,66 @@
+package remote
+
+import (
+	"encoding/gob"
+	"reflect"
+	"sync"
+)
+
+// transport multiplexes several local channels over one underlying
+// encoder/decoder pair. Outgoing frames are serialized with a mutex, since
+// a gob stream isn't safe for concurrent writers; incoming frames are
+// demultiplexed by argument name to the channel registered for it.
+type transport struct {
+	encMu sync.Mutex
+	enc   *gob.Encoder
+	dec   *gob.Decoder
+}
+
+func newTransport(enc *gob.Encoder, dec *gob.Decoder) *transport {
+	return &transport{enc: enc, dec: dec}
+}
+
+// send relays values received from ch to the remote side under the given
+// argument name, until ch is closed, at which point it sends a Close frame
+// and returns.
+func (t *transport) send(arg string, ch reflect.Value) {
+	for {
+		v, ok := ch.Recv()
+		if !ok {
+			t.encMu.Lock()
+			t.enc.Encode(frame{Arg: arg, Close: true})
+			t.encMu.Unlock()
+			return
+		}
+		t.encMu.Lock()
+		err := t.enc.Encode(frame{Arg: arg, Value: v.Interface()})
+		t.encMu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// demux reads frames from the connection and sends each one's Value on the
+// channel registered for its Arg name, until the connection fails or every
+// registered channel has been closed by a Close frame. Meant to be run in
+// its own goroutine; only one demux should run per transport.
+func (t *transport) demux(chans map[string]reflect.Value) {
+	remaining := len(chans)
+	for remaining > 0 {
+		var f frame
+		if err := t.dec.Decode(&f); err != nil {
+			return
+		}
+		ch, ok := chans[f.Arg]
+		if !ok {
+			continue
+		}
+		if f.Close {
+			ch.Close()
+			remaining--
+			continue
+		}
+		ch.Send(reflect.ValueOf(f.Value))
+	}
+}
@@ -0,0 +1,182 @@
+package glow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/awalterschulze/gographviz"
+)
+
+// ErrNoDot is returned by Graph.RenderToFormat when the "dot" binary cannot
+// be found on the system PATH.
+var ErrNoDot = errors.New("glow: dot binary not found in PATH")
+
+// connection records a single channel created by Graph.Connect, along with
+// enough information to render it as a labeled edge.
+type connection struct {
+	chanName  string
+	size      int
+	elemType  reflect.Type
+	endpoints []string // "NodeName:ArgName" entries, in the order given to Connect.
+}
+
+// RenderOptions controls the global graph attributes used by Graph.Render.
+type RenderOptions struct {
+	Rankdir  string // Defaults to "LR".
+	Fontname string
+	Bgcolor  string
+}
+
+// AddNodeWithAttrs: Like AddNode, but also records graphviz attributes
+// (e.g. "color", "fillcolor", "tooltip", "URL") to apply to the node when
+// rendered.
+func (g *Graph) AddNodeWithAttrs(fn interface{}, name string, attrs map[string]string, argNames ...string) {
+	g.AddNode(fn, name, argNames...)
+	for key, val := range attrs {
+		g.SetNodeAttr(name, key, val)
+	}
+}
+
+// SetNodeAttr: Set a single graphviz attribute on an already-added node.
+func (g *Graph) SetNodeAttr(name, key, val string) {
+	node, ok := g.nodes[name]
+	if !ok {
+		panic("Node not found: " + name)
+	}
+	if node.attrs == nil {
+		node.attrs = make(map[string]string)
+	}
+	node.attrs[key] = val
+}
+
+// Cluster: Group the named nodes into a graphviz subgraph cluster. Clusters
+// are rendered as "cluster_<name>" subgraphs by Render.
+func (g *Graph) Cluster(name string, nodeNames ...string) {
+	for _, n := range nodeNames {
+		if _, ok := g.nodes[n]; !ok {
+			panic("Node not found: " + n)
+		}
+	}
+	if g.clusters == nil {
+		g.clusters = make(map[string][]string)
+	}
+	g.clusters[name] = append(g.clusters[name], nodeNames...)
+}
+
+// Render: Build a gographviz graph from the current nodes and connections
+// and return its DOT representation, honoring opts for global attributes.
+func (g *Graph) Render(opts RenderOptions) (string, error) {
+	// NewEscape, rather than NewGraph, so that free-text attribute values
+	// coming from outside this package (SetNodeAttr, AddNodeWithAttrs,
+	// cluster and remote-node names) can't break out of their quoting and
+	// inject arbitrary DOT attributes or subgraphs.
+	gv := gographviz.NewEscape()
+	if err := gv.SetDir(true); err != nil {
+		return "", err
+	}
+	if err := gv.SetName("glow"); err != nil {
+		return "", err
+	}
+
+	rankdir := opts.Rankdir
+	if rankdir == "" {
+		rankdir = "LR"
+	}
+	if err := gv.AddAttr("glow", "rankdir", rankdir); err != nil {
+		return "", err
+	}
+	if opts.Fontname != "" {
+		if err := gv.AddAttr("glow", "fontname", opts.Fontname); err != nil {
+			return "", err
+		}
+	}
+	if opts.Bgcolor != "" {
+		if err := gv.AddAttr("glow", "bgcolor", opts.Bgcolor); err != nil {
+			return "", err
+		}
+	}
+
+	clustered := make(map[string]bool)
+	for cluster, names := range g.clusters {
+		clusterName := "cluster_" + cluster
+		if err := gv.AddSubGraph("glow", clusterName, map[string]string{"label": cluster}); err != nil {
+			return "", err
+		}
+		for _, name := range names {
+			clustered[name] = true
+			if err := addNode(gv, clusterName, g.nodes[name]); err != nil {
+				return "", err
+			}
+		}
+	}
+	for name, node := range g.nodes {
+		if clustered[name] {
+			continue
+		}
+		if err := addNode(gv, "glow", node); err != nil {
+			return "", err
+		}
+	}
+
+	for _, conn := range g.conns {
+		if err := gv.AddNode("glow", conn.chanName, map[string]string{
+			"label": fmt.Sprintf("%v, size %d", conn.elemType, conn.size),
+			"shape": "box",
+		}); err != nil {
+			return "", err
+		}
+		for _, ep := range conn.endpoints {
+			name, port := splitNamePort(ep)
+			var attrs map[string]string
+			if addr, ok := g.remotes[name]; ok {
+				attrs = map[string]string{"label": "tcp://" + addr}
+			}
+			if strings.HasSuffix(port, "Out") {
+				if err := gv.AddEdge(name, conn.chanName, true, attrs); err != nil {
+					return "", err
+				}
+			} else {
+				if err := gv.AddEdge(conn.chanName, name, true, attrs); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	return gv.String(), nil
+}
+
+func addNode(gv *gographviz.Escape, parent string, node *Node) error {
+	attrs := map[string]string{"label": node.recordLabel(), "shape": "record"}
+	for k, v := range node.attrs {
+		attrs[k] = v
+	}
+	return gv.AddNode(parent, node.name, attrs)
+}
+
+// RenderToFormat: Shell out to the "dot" binary to render the graph
+// directly to the given format ("svg", "png", "pdf", ...). Returns ErrNoDot
+// if dot is not installed.
+func (g *Graph) RenderToFormat(opts RenderOptions, format string) ([]byte, error) {
+	if _, err := exec.LookPath("dot"); err != nil {
+		return nil, ErrNoDot
+	}
+
+	dot, err := g.Render(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("dot", "-T"+format)
+	cmd.Stdin = bytes.NewBufferString(dot)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("glow: dot render failed: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,265 @@
+package glow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// NodeError wraps an error (or recovered panic) from a single node's run
+// function, as returned by Graph.RunContext.
+type NodeError struct {
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("glow: node %q: %v", e.Node, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// Validate: Check the graph for problems that would otherwise surface as a
+// panic deep inside SetArg once Run or RunContext is called: unset
+// arguments, nodes with no connections at all (a graph of a single node is
+// not reported for this), and connections whose endpoints disagree on
+// element type. Returns nil if the graph looks runnable.
+func (g *Graph) Validate() error {
+	var result *multierror.Error
+
+	connected := make(map[string]bool)
+	for _, conn := range g.conns {
+		for _, ep := range conn.endpoints {
+			name, _ := splitNamePort(ep)
+			connected[name] = true
+		}
+
+		for _, ep := range conn.endpoints {
+			name, port := splitNamePort(ep)
+			node, ok := g.nodes[name]
+			if !ok {
+				result = multierror.Append(result, fmt.Errorf("glow: connection %q: unknown node %q", conn.chanName, name))
+				continue
+			}
+			argType, ok := node.ArgType(port)
+			if !ok {
+				result = multierror.Append(result, fmt.Errorf("glow: connection %q: node %q has no argument %q", conn.chanName, name, port))
+				continue
+			}
+			if argType.Elem() != conn.elemType {
+				result = multierror.Append(result, fmt.Errorf(
+					"glow: connection %q: node %q argument %q has element type %v, want %v",
+					conn.chanName, name, port, argType.Elem(), conn.elemType))
+			}
+		}
+	}
+
+	for name, node := range g.nodes {
+		if unset := node.UnsetArgs(); len(unset) > 0 {
+			result = multierror.Append(result, fmt.Errorf("glow: node %q has unset arguments: %v", name, unset))
+		}
+		if len(g.nodes) > 1 && !connected[name] {
+			result = multierror.Append(result, fmt.Errorf("glow: node %q is unreachable: it has no connections", name))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// RunContext: Like Run, but supervised. Each node runs in a goroutine
+// tracked by an internal sync.WaitGroup; a panic in a node function is
+// recovered and reported as a *NodeError rather than crashing the process.
+// Validate is run first.
+//
+// On the first node error, or when ctx is done, every node's input
+// channels (the ones it receives on) are closed in reverse-topological
+// order, so that nodes downstream of wherever things went wrong can drain
+// whatever is left in their buffers and return on their own, rather than
+// being killed mid-flight. RunContext then waits for every node to return
+// and returns a single error aggregating everything that went wrong.
+func (g *Graph) RunContext(ctx context.Context) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	errCh := make(chan *NodeError, len(g.nodes))
+	var wg sync.WaitGroup
+
+	runOne := func(node *Node) {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- &NodeError{Node: node.name, Err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		node.Run()
+	}
+
+	var fgNode *Node
+	for _, node := range g.nodes {
+		if node.name == g.fgName {
+			fgNode = node
+			continue
+		}
+		wg.Add(1)
+		go runOne(node)
+	}
+	if fgNode != nil {
+		wg.Add(1)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Watch for cancellation or a node error on its own goroutine, running
+	// concurrently with the foreground node's call below. Without this, a
+	// foreground node blocked on a channel read (e.g. waiting on a sibling
+	// that panicked, or on ctx being canceled) would never let control
+	// reach a select watching for those things: nothing would ever close
+	// its input channels to unblock it, and RunContext would hang forever.
+	var result *multierror.Error
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		select {
+		case <-ctx.Done():
+			result = multierror.Append(result, ctx.Err())
+		case nerr := <-errCh:
+			result = multierror.Append(result, nerr)
+		case <-done:
+		}
+		g.closeInputsReverseTopological()
+	}()
+
+	if fgNode != nil {
+		runOne(fgNode)
+	}
+
+	<-shutdown
+	wg.Wait()
+
+	for {
+		select {
+		case nerr := <-errCh:
+			result = multierror.Append(result, nerr)
+			continue
+		default:
+		}
+		break
+	}
+
+	return result.ErrorOrNil()
+}
+
+// closeInputsReverseTopological closes every node's receiving channels,
+// starting with the nodes furthest downstream and working back towards the
+// sources. Each underlying channel is closed at most once, even if shared
+// by multiple receivers.
+func (g *Graph) closeInputsReverseTopological() {
+	order := g.topologicalOrder()
+
+	closed := make(map[interface{}]bool)
+	for i := len(order) - 1; i >= 0; i-- {
+		node, ok := g.nodes[order[i]]
+		if !ok {
+			continue
+		}
+		for _, arg := range node.args {
+			if arg.name == "globals" || isOutPort(arg.name) || !arg.val.IsValid() {
+				continue
+			}
+			key := arg.val.Pointer()
+			if closed[key] {
+				continue
+			}
+			closed[key] = true
+			closeChan(arg.val)
+		}
+	}
+}
+
+// closeChan closes ch, tolerating the case where a node (or, for an
+// instrumented connection, its relay goroutine) has already closed it as
+// part of its own normal shutdown.
+func closeChan(ch reflect.Value) {
+	defer func() { recover() }()
+	ch.Close()
+}
+
+// topologicalOrder returns the graph's node names in dependency order
+// (sources before sinks), derived from Connect's "Out"-suffixed endpoints.
+// Nodes not involved in any connection come out with the sources, and
+// nodes involved in a cycle are appended in map-iteration order at the end.
+func (g *Graph) topologicalOrder() []string {
+	edges := make(map[string]map[string]bool) // producer -> set of consumers
+	indegree := make(map[string]int)
+
+	for name := range g.nodes {
+		indegree[name] = 0
+	}
+
+	for _, conn := range g.conns {
+		var producers, consumers []string
+		for _, ep := range conn.endpoints {
+			name, port := splitNamePort(ep)
+			if isOutPort(port) {
+				producers = append(producers, name)
+			} else {
+				consumers = append(consumers, name)
+			}
+		}
+		for _, p := range producers {
+			if edges[p] == nil {
+				edges[p] = make(map[string]bool)
+			}
+			for _, c := range consumers {
+				if p == c || edges[p][c] {
+					continue
+				}
+				edges[p][c] = true
+				indegree[c]++
+			}
+		}
+	}
+
+	var queue, order []string
+	for name, d := range indegree {
+		if d == 0 {
+			queue = append(queue, name)
+		}
+	}
+
+	visited := make(map[string]bool)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+		order = append(order, name)
+		for c := range edges[name] {
+			indegree[c]--
+			if indegree[c] == 0 {
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	// Anything left out (a cycle) still needs to be closed eventually.
+	for name := range g.nodes {
+		if !visited[name] {
+			order = append(order, name)
+		}
+	}
+
+	return order
+}
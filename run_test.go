@@ -0,0 +1,95 @@
+package glow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRunContextForegroundDeadlock exercises the scenario the foreground
+// node exists specifically to support: a node that blocks on a channel
+// read, run via SetForeground, alongside a sibling that errors out. Before
+// the foreground node's synchronous call was decoupled from the
+// cancellation/error watcher, this hung forever instead of returning the
+// sibling's error.
+func TestRunContextForegroundDeadlock(t *testing.T) {
+	blocker := func(globals interface{}, in chan int) {
+		<-in
+	}
+	panicker := func(globals interface{}, out chan int) {
+		panic("boom")
+	}
+
+	g := NewGraph(nil)
+	g.AddNode(blocker, "blocker", "in")
+	g.AddNode(panicker, "panicker", "out")
+	g.Connect(1, "panicker:out", "blocker:in")
+	g.SetForeground("blocker")
+
+	done := make(chan error, 1)
+	go func() { done <- g.RunContext(context.Background()) }()
+
+	select {
+	case err := <-done:
+		var nerr *NodeError
+		if !errors.As(err, &nerr) || nerr.Node != "panicker" {
+			t.Fatalf("RunContext() = %v, want a *NodeError for node %q", err, "panicker")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext deadlocked with a blocked foreground node")
+	}
+}
+
+// TestRunContextForegroundCtxCancel covers the other half of the same
+// deadlock: a canceled ctx, with no sibling error, must still unblock a
+// foreground node that's waiting on a channel read.
+func TestRunContextForegroundCtxCancel(t *testing.T) {
+	blocker := func(globals interface{}, in chan int) {
+		<-in
+	}
+	feeder := func(globals interface{}, out chan int) {
+		// Returns immediately without sending or closing out; only ctx
+		// cancellation, via closing blocker's input, ends the run.
+	}
+
+	g := NewGraph(nil)
+	g.AddNode(blocker, "blocker", "in")
+	g.AddNode(feeder, "feeder", "out")
+	g.Connect(1, "feeder:out", "blocker:in")
+	g.SetForeground("blocker")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- g.RunContext(ctx) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("RunContext() = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext deadlocked with a blocked foreground node")
+	}
+}
+
+func TestRunContextNoForeground(t *testing.T) {
+	ok := make(chan struct{})
+	fn := func(globals interface{}) {
+		close(ok)
+	}
+
+	g := NewGraph(nil)
+	g.AddNode(fn, "n")
+
+	if err := g.RunContext(context.Background()); err != nil {
+		t.Fatalf("RunContext() = %v, want nil", err)
+	}
+	select {
+	case <-ok:
+	default:
+		t.Fatal("node never ran")
+	}
+}
@@ -0,0 +1,45 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnnylee/glow"
+)
+
+// addNode adds a node to g, translating any panic from glow.Graph.AddNode
+// (e.g. a duplicate name, or more argument names than fn's signature
+// accepts) into a plain error.
+func addNode(g *glow.Graph, fn interface{}, ns NodeSpec) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("spec: adding node %q: %v", ns.Name, r)
+		}
+	}()
+	g.AddNode(fn, ns.Name, ns.Args...)
+	return nil
+}
+
+// connect wires up a connection, translating any panic from
+// glow.Graph.Connect (e.g. an argument already wired elsewhere) into a
+// plain error. The element type and endpoint existence checks in Load
+// happen first, so a panic here generally indicates a duplicate wiring
+// that Load's own bookkeeping didn't catch.
+func connect(g *glow.Graph, cs ConnSpec) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("spec: connecting %v: %v", cs.Endpoints, r)
+		}
+	}()
+	g.Connect(cs.Size, cs.Endpoints...)
+	return nil
+}
+
+// splitEndpoint parses a "NodeName:ArgName" endpoint string.
+func splitEndpoint(s string) (name, port string, err error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", &UnknownEndpointError{Endpoint: s}
+	}
+	return parts[0], parts[1], nil
+}
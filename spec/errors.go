@@ -0,0 +1,62 @@
+package spec
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnknownFuncError is returned when a node spec names a function key that
+// is not present in the Registry passed to Load.
+type UnknownFuncError struct {
+	Node string
+	Func string
+}
+
+func (e *UnknownFuncError) Error() string {
+	return fmt.Sprintf("spec: node %q references unknown function %q", e.Node, e.Func)
+}
+
+// UnknownEndpointError is returned when a connection, or the foreground
+// field, references a "Node:Arg" endpoint that does not exist.
+type UnknownEndpointError struct {
+	Endpoint string
+}
+
+func (e *UnknownEndpointError) Error() string {
+	return fmt.Sprintf("spec: unknown endpoint %q", e.Endpoint)
+}
+
+// DuplicateArgError is returned when the same "Node:Arg" endpoint is wired
+// into more than one connection.
+type DuplicateArgError struct {
+	Endpoint string
+}
+
+func (e *DuplicateArgError) Error() string {
+	return fmt.Sprintf("spec: endpoint %q is wired more than once", e.Endpoint)
+}
+
+// MissingArgError is returned when a node declares an argument (in its
+// NodeSpec.Args) that no connection ever wires up.
+type MissingArgError struct {
+	Node string
+	Arg  string
+}
+
+func (e *MissingArgError) Error() string {
+	return fmt.Sprintf("spec: node %q argument %q is never connected", e.Node, e.Arg)
+}
+
+// TypeMismatchError is returned when the endpoints of a single connection
+// don't all agree on the channel's element type.
+type TypeMismatchError struct {
+	ConnIndex int
+	Endpoint  string
+	Want      reflect.Type
+	Got       reflect.Type
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("spec: connection %d: endpoint %q has type %v, want %v",
+		e.ConnIndex, e.Endpoint, e.Got, e.Want)
+}
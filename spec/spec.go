@@ -0,0 +1,125 @@
+// Package spec lets a glow.Graph be described declaratively, in YAML or
+// JSON, instead of being wired together in Go code.
+package spec
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/johnnylee/glow"
+	"gopkg.in/yaml.v3"
+)
+
+// Registry maps the function keys used in a spec file to the Go functions
+// they refer to. Every function a spec file names must have an entry here.
+type Registry map[string]interface{}
+
+// NodeSpec describes a single node: the function to run (looked up in the
+// Registry by Func) and the names of its non-globals arguments.
+type NodeSpec struct {
+	Name string   `yaml:"name" json:"name"`
+	Func string   `yaml:"func" json:"func"`
+	Args []string `yaml:"args" json:"args"`
+}
+
+// ConnSpec describes a single channel connection: its buffer size and the
+// "NodeName:ArgName" endpoints that share it.
+type ConnSpec struct {
+	Size      int      `yaml:"size" json:"size"`
+	Endpoints []string `yaml:"endpoints" json:"endpoints"`
+}
+
+// GraphSpec is the top-level shape of a spec file.
+type GraphSpec struct {
+	Nodes       []NodeSpec `yaml:"nodes" json:"nodes"`
+	Connections []ConnSpec `yaml:"connections" json:"connections"`
+	Foreground  string     `yaml:"foreground" json:"foreground"`
+}
+
+// Load parses a declarative graph description from r (YAML, or JSON, which
+// is valid YAML) and builds a wired *glow.Graph from it. reg supplies the
+// Go functions named by each node's Func field; globals is passed through
+// to glow.NewGraph unchanged.
+//
+// Every function key, node name, and endpoint is validated, as is agreement
+// between the element types of every endpoint on a single connection, and
+// that every argument a node declares is wired to exactly one connection.
+// Load never panics: a spec failing one of these checks comes back as one
+// of the typed errors in this package. A parse failure, or a node or
+// connection that glow itself rejects (e.g. a duplicate node name), comes
+// back as a plain error.
+func Load(r io.Reader, reg Registry, globals interface{}) (*glow.Graph, error) {
+	var gs GraphSpec
+	if err := yaml.NewDecoder(r).Decode(&gs); err != nil {
+		return nil, fmt.Errorf("spec: parse: %w", err)
+	}
+
+	g := glow.NewGraph(globals)
+
+	for _, ns := range gs.Nodes {
+		fn, ok := reg[ns.Func]
+		if !ok {
+			return nil, &UnknownFuncError{Node: ns.Name, Func: ns.Func}
+		}
+		if err := addNode(g, fn, ns); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, cs := range gs.Connections {
+		var want reflect.Type
+		for _, ep := range cs.Endpoints {
+			name, port, err := splitEndpoint(ep)
+			if err != nil {
+				return nil, err
+			}
+			node, ok := g.Node(name)
+			if !ok {
+				return nil, &UnknownEndpointError{Endpoint: ep}
+			}
+			chanType, ok := node.ArgType(port)
+			if !ok {
+				return nil, &UnknownEndpointError{Endpoint: ep}
+			}
+			if seen[ep] {
+				return nil, &DuplicateArgError{Endpoint: ep}
+			}
+			seen[ep] = true
+
+			if want == nil {
+				want = chanType
+			} else if chanType != want {
+				return nil, &TypeMismatchError{ConnIndex: i, Endpoint: ep, Want: want, Got: chanType}
+			}
+		}
+	}
+
+	for _, ns := range gs.Nodes {
+		for _, arg := range ns.Args {
+			if !seen[ns.Name+":"+arg] {
+				return nil, &MissingArgError{Node: ns.Name, Arg: arg}
+			}
+		}
+	}
+
+	// Every connection is now known to be valid, so it's safe to start
+	// wiring: glow.Connect can start a relay goroutine for an instrumented
+	// connection, and we don't want one of those leaked because a later
+	// connection, or a node's missing argument, fails validation.
+	for _, cs := range gs.Connections {
+		if err := connect(g, cs); err != nil {
+			return nil, err
+		}
+	}
+
+	if gs.Foreground != "" {
+		if _, ok := g.Node(gs.Foreground); !ok {
+			return nil, &UnknownEndpointError{Endpoint: gs.Foreground}
+		}
+		g.SetForeground(gs.Foreground)
+	}
+
+	return g, nil
+}
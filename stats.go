@@ -0,0 +1,176 @@
+package glow
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ChannelStats is a point-in-time snapshot of one connection's traffic, as
+// returned by Graph.Stats.
+type ChannelStats struct {
+	Name             string  // The connection's name, matching the DOT output (e.g. "chan_0").
+	Size             int     // Configured buffer size.
+	Sent             uint64  // Total values sent.
+	Recv             uint64  // Total values received.
+	Occupancy        int     // Current number of buffered values, across both the producer and consumer buffer (capacity 2*Size).
+	FullnessIntegral float64 // Time-integrated occupancy/capacity, in buffer-seconds.
+	BlockedOnSend    bool    // True if both buffers are currently full. Always false for an unbuffered (Size 0) connection: occupancy can't observe that case.
+	BlockedOnRecv    bool    // True if both buffers are currently empty.
+}
+
+// channelRuntime tracks the live state of one connection. Instrumenting a
+// channel means interposing a relay goroutine between the sender(s) and
+// receiver(s): Connect hands the sender a "producer" channel and the
+// receiver(s) a separate "consumer" channel of the same type, and a relay
+// goroutine copies values from one to the other, counting as it goes. This
+// keeps sent/recv counts accurate without requiring node functions to
+// change how they use their channels.
+//
+// Because the connection is really two buffers of size values each, its
+// true combined capacity is 2*size, not size; capacity records that so
+// Occupancy can be compared against it correctly.
+type channelRuntime struct {
+	mu sync.Mutex
+
+	name     string
+	size     int
+	capacity int
+
+	sent, recv uint64
+
+	producer reflect.Value // written to by the sending node(s).
+	consumer reflect.Value // read from by the receiving node(s).
+
+	lastSample       time.Time
+	fullnessIntegral float64
+}
+
+func newChannelRuntime(name string, size int, chanType reflect.Type) *channelRuntime {
+	return &channelRuntime{
+		name:       name,
+		size:       size,
+		capacity:   2 * size,
+		producer:   reflect.MakeChan(chanType, size),
+		consumer:   reflect.MakeChan(chanType, size),
+		lastSample: time.Now(),
+	}
+}
+
+// relay copies values from the producer channel to the consumer channel,
+// counting each one, until the producer channel is closed.
+func (rt *channelRuntime) relay() {
+	for {
+		v, ok := rt.producer.Recv()
+		if !ok {
+			rt.consumer.Close()
+			return
+		}
+		rt.mu.Lock()
+		rt.recv++
+		rt.mu.Unlock()
+
+		rt.consumer.Send(v)
+
+		rt.mu.Lock()
+		rt.sent++
+		rt.mu.Unlock()
+	}
+}
+
+func (rt *channelRuntime) snapshot() ChannelStats {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	occ := rt.producer.Len() + rt.consumer.Len()
+
+	now := time.Now()
+	if rt.capacity > 0 {
+		rt.fullnessIntegral += now.Sub(rt.lastSample).Seconds() * float64(occ) / float64(rt.capacity)
+	}
+	rt.lastSample = now
+
+	return ChannelStats{
+		Name:             rt.name,
+		Size:             rt.size,
+		Sent:             rt.sent,
+		Recv:             rt.recv,
+		Occupancy:        occ,
+		FullnessIntegral: rt.fullnessIntegral,
+		// An unbuffered connection (size 0, so capacity 0) never buffers a
+		// value long enough for Len() to see it, so there's no way to tell
+		// a full buffer from an empty one that way; don't report it as
+		// blocked on send just because occ can never reach a zero capacity.
+		BlockedOnSend: rt.capacity > 0 && occ >= rt.capacity,
+		BlockedOnRecv: occ == 0,
+	}
+}
+
+// Stats: Return a snapshot of every instrumented connection's traffic,
+// keyed by connection name (matching the DOT output, e.g. "chan_0").
+func (g *Graph) Stats() map[string]ChannelStats {
+	out := make(map[string]ChannelStats, len(g.runtimes))
+	for name, rt := range g.runtimes {
+		out[name] = rt.snapshot()
+	}
+	return out
+}
+
+// LiveDotString: Like DotString, but edges are labeled with live
+// "sent/recv (occupancy/size)" traffic counts, and nodes are filled with a
+// color indicating whether they currently look blocked on a send or a
+// receive.
+func (g *Graph) LiveDotString() string {
+	stats := g.Stats()
+
+	blockedSend := make(map[string]bool)
+	blockedRecv := make(map[string]bool)
+	for _, conn := range g.conns {
+		st, ok := stats[conn.chanName]
+		if !ok {
+			continue
+		}
+		for _, ep := range conn.endpoints {
+			name, port := splitNamePort(ep)
+			if isOutPort(port) {
+				if st.BlockedOnSend {
+					blockedSend[name] = true
+				}
+			} else if st.BlockedOnRecv {
+				blockedRecv[name] = true
+			}
+		}
+	}
+
+	s := "digraph {"
+	s += "\ngraph [ rankdir=\"LR\" ];"
+	for _, node := range g.nodes {
+		fill := ""
+		switch {
+		case blockedSend[node.name]:
+			fill = ", style=filled, fillcolor=orange"
+		case blockedRecv[node.name]:
+			fill = ", style=filled, fillcolor=lightblue"
+		}
+		s += fmt.Sprintf("\n%v [\nlabel = \"%v\"\nshape = record%v\n]", node.name, node.recordLabel(), fill)
+	}
+	for _, conn := range g.conns {
+		st := stats[conn.chanName]
+		label := fmt.Sprintf("%v/%v (%v/%v)", st.Sent, st.Recv, st.Occupancy, st.Size)
+		for _, ep := range conn.endpoints {
+			name, port := splitNamePort(ep)
+			if isOutPort(port) {
+				s += fmt.Sprintf("\n%v:%v -> %v [\nlabel=\"%v\"\n]", name, port, conn.chanName, label)
+			} else {
+				s += fmt.Sprintf("\n%v -> %v:%v [\nlabel=\"%v\"\n]", conn.chanName, name, port, label)
+			}
+		}
+	}
+	s += "\n}"
+	return s
+}
+
+func isOutPort(port string) bool {
+	return len(port) >= 3 && port[len(port)-3:] == "Out"
+}
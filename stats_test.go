@@ -0,0 +1,64 @@
+package glow
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestChannelRuntimeSnapshotBuffered(t *testing.T) {
+	rt := newChannelRuntime("chan_0", 2, reflect.TypeOf(make(chan int)))
+
+	if got := rt.snapshot(); got.Occupancy != 0 || !got.BlockedOnRecv || got.BlockedOnSend {
+		t.Fatalf("empty snapshot = %+v, want occupancy 0, blocked on recv, not blocked on send", got)
+	}
+
+	// Fill both the producer and consumer buffers directly (bypassing the
+	// relay goroutine, which isn't running) to reach capacity 2*size = 4.
+	rt.producer.Send(reflect.ValueOf(1))
+	rt.producer.Send(reflect.ValueOf(2))
+	rt.consumer.Send(reflect.ValueOf(3))
+	rt.consumer.Send(reflect.ValueOf(4))
+
+	got := rt.snapshot()
+	if got.Occupancy != 4 {
+		t.Fatalf("Occupancy = %d, want 4 (2*size)", got.Occupancy)
+	}
+	if !got.BlockedOnSend {
+		t.Fatal("BlockedOnSend = false, want true once both buffers are full")
+	}
+	if got.BlockedOnRecv {
+		t.Fatal("BlockedOnRecv = true, want false: there are values to receive")
+	}
+
+	rt.producer.Recv()
+	rt.producer.Recv()
+	rt.consumer.Recv()
+	rt.consumer.Recv()
+
+	got = rt.snapshot()
+	if got.Occupancy != 0 {
+		t.Fatalf("Occupancy = %d, want 0 after draining", got.Occupancy)
+	}
+	if got.BlockedOnSend {
+		t.Fatal("BlockedOnSend = true, want false: buffers are empty")
+	}
+	if !got.BlockedOnRecv {
+		t.Fatal("BlockedOnRecv = false, want true: buffers are empty")
+	}
+}
+
+func TestChannelRuntimeSnapshotUnbuffered(t *testing.T) {
+	rt := newChannelRuntime("chan_0", 0, reflect.TypeOf(make(chan int)))
+
+	got := rt.snapshot()
+	if got.Occupancy != 0 {
+		t.Fatalf("Occupancy = %d, want 0", got.Occupancy)
+	}
+	if got.BlockedOnSend {
+		t.Fatal("BlockedOnSend = true for an unbuffered connection, want false: occupancy can never observe that case")
+	}
+	if math.IsNaN(got.FullnessIntegral) || math.IsInf(got.FullnessIntegral, 0) {
+		t.Fatalf("FullnessIntegral = %v, want a finite value even with zero capacity", got.FullnessIntegral)
+	}
+}